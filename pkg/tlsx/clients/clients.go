@@ -0,0 +1,196 @@
+// Package clients implements shared types and options used across the
+// various tlsx grabbing backends (ztls, crypto/tls, ...).
+package clients
+
+import (
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"reflect"
+	"strings"
+)
+
+// Options contains configuration options for the tls grabbing clients.
+type Options struct {
+	// Timeout is the connection timeout in seconds
+	Timeout int
+	// ServerName is the optional server name to use for SNI
+	ServerName string
+	// CertsOnly instructs the client to only fetch certificates. Not
+	// supported when MinVersion/MaxVersion forces the tls13 fallback
+	// backend (see MinVersion).
+	CertsOnly bool
+	// VerifyServerCertificate enables verification of the server certificate
+	VerifyServerCertificate bool
+	// MinVersion is the minimum tls version to negotiate (ssl30, tls10, tls11, tls12, tls13).
+	// ssl30 cannot be combined with a tls13 MinVersion/MaxVersion: the
+	// standard library crypto/tls backend tlsx falls back to for TLS 1.3
+	// dropped SSLv3 support entirely.
+	MinVersion string
+	// MaxVersion is the maximum tls version to negotiate (ssl30, tls10, tls11, tls12, tls13)
+	MaxVersion string
+	// ClientHello selects a uTLS ClientHello fingerprint to mimic
+	// (chrome, firefox, safari, ios, android, randomized). Only honoured
+	// by the utls backend.
+	ClientHello string
+	// NoSNI disables sending the SNI ServerName during the handshake.
+	// Honoured by Connect() on the ztls and ctls backends; the utls backend
+	// ignores it and always sends SNI. For a full no-SNI vs SNI comparison
+	// use ConnectDifferentialSNI instead.
+	NoSNI bool
+	// ALPN is a comma-separated list of application protocols to
+	// advertise during the handshake (e.g. h2,http/1.1,acme-tls/1,dot)
+	ALPN string
+	// ClientCertFile is the path to a PEM client certificate presented for mTLS
+	ClientCertFile string
+	// ClientKeyFile is the path to the PEM private key for ClientCertFile
+	ClientKeyFile string
+	// ClientCAFile is the path to a PEM CA bundle used to verify the server certificate
+	ClientCAFile string
+}
+
+// Response contains the response from a tls grab
+type Response struct {
+	// Host is the host the connection was made to
+	Host string
+	// Port is the port the connection was made to
+	Port string
+	// Version is the negotiated tls version
+	Version string
+	// TLSConnection is the backend used to perform the handshake (ztls, ctls, utls)
+	TLSConnection string
+	// Leaf is the leaf certificate returned by the server
+	Leaf CertificateResponse
+	// Chain is the remaining certificate chain returned by the server
+	Chain []CertificateResponse
+	// ClientHello is the uTLS ClientHello fingerprint presented during
+	// the handshake, populated only by the utls backend.
+	ClientHello string
+	// NegotiatedProtocol is the application protocol negotiated via ALPN
+	NegotiatedProtocol string
+	// NegotiatedCipher is the cipher suite negotiated during the handshake
+	NegotiatedCipher string
+	// SupportedALPNs is the set of application protocols the server
+	// accepted when each was offered individually
+	SupportedALPNs []string
+	// SupportedCiphers is the set of cipher/version pairs accepted by
+	// the server, populated by an EnumerateCiphers scan
+	SupportedCiphers []CipherResponse
+	// ServerCipherPreference is true if the server picks the same cipher
+	// regardless of the order the client offers its accepted ciphers in
+	ServerCipherPreference bool
+	// ClientAuthRequested is true if the server sent a CertificateRequest
+	// during the handshake, i.e. it expects mutual TLS. Populated by the
+	// ztls and ctls backends via their tls.Config.GetClientCertificate
+	// callback.
+	ClientAuthRequested bool
+	// AcceptedIssuers is the list of certificate authority distinguished
+	// names the server will accept a client certificate from, parsed
+	// from its CertificateRequest. Populated by the ztls and ctls backends.
+	AcceptedIssuers []string
+}
+
+// CipherResponse contains the result of probing a single cipher/version pair
+type CipherResponse struct {
+	// Version is the tls version the cipher was tested against
+	Version string
+	// Cipher is the name of the cipher suite
+	Cipher string
+	// Insecure flags ciphers considered weak (export, NULL, RC4, 3DES, CBC-with-SHA1)
+	Insecure bool
+}
+
+// weakCipherSubstrings are cipher-name fragments considered insecure
+var weakCipherSubstrings = []string{"EXPORT", "NULL", "RC4", "3DES", "DES"}
+
+// IsWeakCipher reports whether a cipher suite name is considered weak:
+// export-grade, NULL, RC4, 3DES, or CBC-mode with SHA1.
+func IsWeakCipher(name string) bool {
+	for _, substr := range weakCipherSubstrings {
+		if strings.Contains(name, substr) {
+			return true
+		}
+	}
+	return strings.Contains(name, "_CBC_SHA") && !strings.Contains(name, "_CBC_SHA256") && !strings.Contains(name, "_CBC_SHA384")
+}
+
+// DifferentialResponse contains the result of a no-SNI vs SNI differential
+// scan against the same host.
+type DifferentialResponse struct {
+	// NoSNI is the response captured without setting the SNI ServerName
+	NoSNI *Response
+	// SNI is the response captured with the SNI ServerName set
+	SNI *Response
+	// VersionDiffers is true if the negotiated tls version differs between the two handshakes
+	VersionDiffers bool
+	// CertificateDiffers is true if the leaf certificate or chain differs between the two handshakes
+	CertificateDiffers bool
+}
+
+// CertificatesDiffer reports whether two responses returned different
+// certificate material, comparing both the leaf and the full chain
+// rather than just the leaf's common name.
+func CertificatesDiffer(a, b *Response) bool {
+	return !reflect.DeepEqual(a.Leaf, b.Leaf) || !reflect.DeepEqual(a.Chain, b.Chain)
+}
+
+// CertificateResponse contains the response for a certificate
+type CertificateResponse struct {
+	// DNSNames is a list of DNS names on the certificate
+	DNSNames []string
+	// Emails is a list of emails on the certificate
+	Emails []string
+	// IssuerCommonName is the common name of the certificate issuer
+	IssuerCommonName string
+	// IssuerOrganization is the organization of the certificate issuer
+	IssuerOrganization []string
+	// SubjectCommonName is the common name of the certificate subject
+	SubjectCommonName string
+	// SubjectOrganization is the organization of the certificate subject
+	SubjectOrganization []string
+}
+
+// Implementation is an interface implemented by all tls grabbing backends
+type Implementation interface {
+	// Connect connects to a host and grabs the response data
+	Connect(hostname, port string) (*Response, error)
+}
+
+// SplitAndTrim splits a comma-separated list and trims whitespace from
+// each element, e.g. for Options.ALPN.
+func SplitAndTrim(value string) []string {
+	parts := strings.Split(value, ",")
+	for i, part := range parts {
+		parts[i] = strings.TrimSpace(part)
+	}
+	return parts
+}
+
+// IssuerDN best-effort decodes a DER-encoded distinguished name from a
+// CertificateRequest's certificate authority list into a readable string.
+func IssuerDN(der []byte) string {
+	var rdn pkix.RDNSequence
+	if _, err := asn1.Unmarshal(der, &rdn); err != nil {
+		return ""
+	}
+	var name pkix.Name
+	name.FillFromRDNSequence(&rdn)
+	return name.String()
+}
+
+// EnumerateALPN sweeps the supplied list of application protocols, calling
+// connectWithProto once per protocol, and returns the ones the server
+// negotiated. Backends pass a closure that offers a single protocol and
+// performs the handshake.
+func EnumerateALPN(protocols []string, connectWithProto func(proto string) (*Response, error)) []string {
+	var supported []string
+	for _, proto := range protocols {
+		resp, err := connectWithProto(proto)
+		if err != nil {
+			continue
+		}
+		if resp.NegotiatedProtocol == proto {
+			supported = append(supported, proto)
+		}
+	}
+	return supported
+}
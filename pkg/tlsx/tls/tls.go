@@ -0,0 +1,259 @@
+// Package tls implements a tls grabbing implementation using the
+// standard library crypto/tls package. It is primarily used as a
+// fallback for protocol versions not supported by zcrypto/tls, such
+// as TLS 1.3.
+package tls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// Client is a TLS grabbing client using crypto/tls
+type Client struct {
+	dialer    *net.Dialer
+	tlsConfig *tls.Config
+	// noSNI makes Connect behave like ConnectWithNoSNI
+	noSNI bool
+}
+
+// versionStringToTLSVersion converts tls version string to version
+var versionStringToTLSVersion = map[string]uint16{
+	"tls10": tls.VersionTLS10,
+	"tls11": tls.VersionTLS11,
+	"tls12": tls.VersionTLS12,
+	"tls13": tls.VersionTLS13,
+}
+
+// versionToTLSVersionString converts tls version to version string
+var versionToTLSVersionString = map[uint16]string{
+	tls.VersionTLS10: "tls10",
+	tls.VersionTLS11: "tls11",
+	tls.VersionTLS12: "tls12",
+	tls.VersionTLS13: "tls13",
+}
+
+// New creates a new grabbing client using crypto/tls
+func New(options *clients.Options) (*Client, error) {
+	c := &Client{
+		dialer: &net.Dialer{
+			Timeout: time.Duration(options.Timeout) * time.Second,
+		},
+		tlsConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS10,
+			MaxVersion:         tls.VersionTLS13,
+			InsecureSkipVerify: !options.VerifyServerCertificate,
+		},
+		noSNI: options.NoSNI,
+	}
+	if options.ServerName != "" {
+		c.tlsConfig.ServerName = options.ServerName
+	}
+	if options.MinVersion != "" {
+		version, ok := versionStringToTLSVersion[options.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid min version specified: %s", options.MinVersion)
+		}
+		c.tlsConfig.MinVersion = version
+	}
+	if options.MaxVersion != "" {
+		version, ok := versionStringToTLSVersion[options.MaxVersion]
+		if !ok {
+			return nil, fmt.Errorf("invalid max version specified: %s", options.MaxVersion)
+		}
+		c.tlsConfig.MaxVersion = version
+	}
+	if options.ALPN != "" {
+		c.tlsConfig.NextProtos = clients.SplitAndTrim(options.ALPN)
+	}
+	if options.ClientCertFile != "" && options.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(options.ClientCertFile, options.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate")
+		}
+		c.tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if options.ClientCAFile != "" {
+		pemBytes, err := os.ReadFile(options.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read client ca file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("could not parse client ca file: %s", options.ClientCAFile)
+		}
+		c.tlsConfig.RootCAs = pool
+	}
+	return c, nil
+}
+
+// Connect connects to a host and grabs the response data
+func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
+	return c.ConnectContext(context.Background(), hostname, port)
+}
+
+// ConnectContext connects to a host and grabs the response data, aborting
+// the dial and handshake as soon as ctx is cancelled or its deadline is exceeded.
+func (c *Client) ConnectContext(ctx context.Context, hostname, port string) (*clients.Response, error) {
+	return c.connect(ctx, hostname, port, c.noSNI)
+}
+
+// ConnectWithNoSNI connects to a host without setting the SNI ServerName,
+// useful for discovering the default vhost certificate served by shared
+// hosting and CDNs.
+func (c *Client) ConnectWithNoSNI(hostname, port string) (*clients.Response, error) {
+	return c.connect(context.Background(), hostname, port, true)
+}
+
+// ConnectWithNoSNIContext is the context-aware variant of ConnectWithNoSNI,
+// used by callers (such as ztls's tls13 fallback) that need to force a
+// no-SNI handshake while still honouring cancellation.
+func (c *Client) ConnectWithNoSNIContext(ctx context.Context, hostname, port string) (*clients.Response, error) {
+	return c.connect(ctx, hostname, port, true)
+}
+
+// ConnectDifferentialSNI performs two handshakes against the same host,
+// one without SNI and one with it set, and returns both responses so the
+// caller can diff the default-vhost certificate against the vhost-specific
+// one.
+func (c *Client) ConnectDifferentialSNI(hostname, port string) (*clients.DifferentialResponse, error) {
+	noSNI, err := c.ConnectWithNoSNI(hostname, port)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect without sni")
+	}
+	withSNI, err := c.connect(context.Background(), hostname, port, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect with sni")
+	}
+	return &clients.DifferentialResponse{
+		NoSNI:              noSNI,
+		SNI:                withSNI,
+		VersionDiffers:     noSNI.Version != withSNI.Version,
+		CertificateDiffers: clients.CertificatesDiffer(noSNI, withSNI),
+	}, nil
+}
+
+func (c *Client) connect(ctx context.Context, hostname, port string, noSNI bool) (*clients.Response, error) {
+	address := net.JoinHostPort(hostname, port)
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, errors.Wrap(err, "could not connect to address")
+	}
+	defer conn.Close()
+
+	cfg := c.cloneTLSConfig()
+	if !noSNI && cfg.ServerName == "" {
+		cfg.ServerName = hostname
+	} else if noSNI {
+		cfg.ServerName = ""
+	}
+
+	var certRequest *tls.CertificateRequestInfo
+	clientCerts := cfg.Certificates
+	cfg.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		certRequest = info
+		if len(clientCerts) > 0 {
+			return &clientCerts[0], nil
+		}
+		return &tls.Certificate{}, nil
+	}
+
+	tlsConn := tls.Client(conn, cfg)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tlsConn.Handshake()
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Unblock the handshake goroutine by forcing the underlying
+		// connection to time out immediately; the goroutine's result
+		// is then discarded once it returns.
+		_ = conn.SetDeadline(time.Now())
+		<-done
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not do tls handshake")
+	}
+	defer tlsConn.Close()
+
+	state := tlsConn.ConnectionState()
+	response := &clients.Response{
+		Host:               hostname,
+		Port:               port,
+		Version:            versionToTLSVersionString[state.Version],
+		TLSConnection:      "ctls",
+		NegotiatedProtocol: state.NegotiatedProtocol,
+		NegotiatedCipher:   tls.CipherSuiteName(state.CipherSuite),
+	}
+	if len(state.PeerCertificates) > 0 {
+		response.Leaf = convertCertificateToResponse(state.PeerCertificates[0])
+		for _, cert := range state.PeerCertificates[1:] {
+			response.Chain = append(response.Chain, convertCertificateToResponse(cert))
+		}
+	}
+	if certRequest != nil {
+		response.ClientAuthRequested = true
+		for _, ca := range certRequest.AcceptableCAs {
+			response.AcceptedIssuers = append(response.AcceptedIssuers, clients.IssuerDN(ca))
+		}
+	}
+	return response, nil
+}
+
+// EnumerateALPN sweeps the supplied list of application protocols,
+// performing one handshake per protocol with only that protocol offered,
+// and returns the set of protocols the server negotiated.
+func (c *Client) EnumerateALPN(hostname, port string, protocols []string) ([]string, error) {
+	return clients.EnumerateALPN(protocols, func(proto string) (*clients.Response, error) {
+		probe := *c
+		cfg := c.cloneTLSConfig()
+		cfg.NextProtos = []string{proto}
+		probe.tlsConfig = cfg
+		return probe.Connect(hostname, port)
+	}), nil
+}
+
+// cloneTLSConfig builds a fresh *tls.Config with the same settings as the
+// client's, field by field. crypto/tls.Config embeds a mutex, so it must
+// never be copied by dereferencing (go vet: assignment copies lock value).
+func (c *Client) cloneTLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion:         c.tlsConfig.MinVersion,
+		MaxVersion:         c.tlsConfig.MaxVersion,
+		InsecureSkipVerify: c.tlsConfig.InsecureSkipVerify,
+		ServerName:         c.tlsConfig.ServerName,
+		NextProtos:         c.tlsConfig.NextProtos,
+		Certificates:       c.tlsConfig.Certificates,
+		RootCAs:            c.tlsConfig.RootCAs,
+	}
+}
+
+func convertCertificateToResponse(cert *x509.Certificate) clients.CertificateResponse {
+	if cert == nil {
+		return clients.CertificateResponse{}
+	}
+	return clients.CertificateResponse{
+		DNSNames:            cert.DNSNames,
+		Emails:              cert.EmailAddresses,
+		IssuerCommonName:    cert.Issuer.CommonName,
+		IssuerOrganization:  cert.Issuer.Organization,
+		SubjectCommonName:   cert.Subject.CommonName,
+		SubjectOrganization: cert.Subject.Organization,
+	}
+}
@@ -0,0 +1,45 @@
+package tls
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// TestConnectContextCancelled ensures ConnectContext returns promptly with
+// ctx.Err() instead of blocking on the dial when the context is already
+// cancelled before the handshake begins.
+func TestConnectContextCancelled(t *testing.T) {
+	client, err := New(&clients.Options{Timeout: 5})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.ConnectContext(ctx, "example.com", "443")
+	if err == nil {
+		t.Fatal("expected an error from ConnectContext with an already-cancelled context")
+	}
+}
+
+// TestConnectDifferentialSNIWrapsNoSNIError ensures ConnectDifferentialSNI
+// reports the no-SNI leg's failure distinctly, rather than silently falling
+// through to the SNI leg, when the server is unreachable.
+func TestConnectDifferentialSNIWrapsNoSNIError(t *testing.T) {
+	client, err := New(&clients.Options{Timeout: 1})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	_, err = client.ConnectDifferentialSNI("127.0.0.1", "0")
+	if err == nil {
+		t.Fatal("expected an error from ConnectDifferentialSNI against an unreachable port")
+	}
+	if !strings.Contains(err.Error(), "could not connect without sni") {
+		t.Fatalf("expected the no-SNI leg's error to be wrapped, got: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+// Package utls implements a tls grabbing implementation that mimics
+// real-world browser ClientHello fingerprints using refraction-networking/utls.
+//
+// This is useful for scanning WAF/CDN-protected origins that reject the
+// ClientHello produced by Go's standard library or by zcrypto/tls.
+package utls
+
+import (
+	"context"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	utls "github.com/refraction-networking/utls"
+)
+
+// Client is a TLS grabbing client using uTLS ClientHello mimicry
+type Client struct {
+	dialer       *net.Dialer
+	clientHello  utls.ClientHelloID
+	fingerprint  string
+	serverName   string
+	insecureSkip bool
+}
+
+// clientHelloIDs maps the supported fingerprint names to their uTLS ids
+var clientHelloIDs = map[string]utls.ClientHelloID{
+	"chrome":     utls.HelloChrome_Auto,
+	"firefox":    utls.HelloFirefox_Auto,
+	"safari":     utls.HelloSafari_Auto,
+	"ios":        utls.HelloIOS_Auto,
+	"android":    utls.HelloAndroid_11_OkHttp,
+	"randomized": utls.HelloRandomized,
+}
+
+// New creates a new grabbing client using uTLS ClientHello mimicry
+func New(options *clients.Options) (*Client, error) {
+	helloID, ok := clientHelloIDs[options.ClientHello]
+	if !ok {
+		return nil, fmt.Errorf("invalid client hello fingerprint specified: %s", options.ClientHello)
+	}
+	return &Client{
+		dialer: &net.Dialer{
+			Timeout: time.Duration(options.Timeout) * time.Second,
+		},
+		clientHello:  helloID,
+		fingerprint:  options.ClientHello,
+		serverName:   options.ServerName,
+		insecureSkip: !options.VerifyServerCertificate,
+	}, nil
+}
+
+// Connect connects to a host and grabs the response data
+func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
+	return c.ConnectContext(context.Background(), hostname, port)
+}
+
+// ConnectContext connects to a host and grabs the response data, aborting
+// the dial and handshake as soon as ctx is cancelled or its deadline is
+// exceeded.
+func (c *Client) ConnectContext(ctx context.Context, hostname, port string) (*clients.Response, error) {
+	address := net.JoinHostPort(hostname, port)
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, errors.Wrap(err, "could not connect to address")
+	}
+	defer conn.Close()
+
+	serverName := c.serverName
+	if serverName == "" {
+		serverName = hostname
+	}
+
+	config := &utls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: c.insecureSkip,
+	}
+	uconn := utls.UClient(conn, config, c.clientHello)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- uconn.Handshake()
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Unblock the handshake goroutine by forcing the underlying
+		// connection to time out immediately; the goroutine's result
+		// is then discarded once it returns.
+		_ = conn.SetDeadline(time.Now())
+		<-done
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "could not do tls handshake")
+	}
+	defer uconn.Close()
+
+	state := uconn.ConnectionState()
+	response := &clients.Response{
+		Host:          hostname,
+		Port:          port,
+		Version:       versionToTLSVersionString[state.Version],
+		TLSConnection: "utls",
+		ClientHello:   c.fingerprint,
+	}
+	if len(state.PeerCertificates) > 0 {
+		response.Leaf = convertCertificateToResponse(state.PeerCertificates[0])
+		for _, cert := range state.PeerCertificates[1:] {
+			response.Chain = append(response.Chain, convertCertificateToResponse(cert))
+		}
+	}
+	return response, nil
+}
+
+// versionToTLSVersionString converts tls version to version string
+var versionToTLSVersionString = map[uint16]string{
+	utls.VersionTLS10: "tls10",
+	utls.VersionTLS11: "tls11",
+	utls.VersionTLS12: "tls12",
+	utls.VersionTLS13: "tls13",
+}
+
+func convertCertificateToResponse(cert *x509.Certificate) clients.CertificateResponse {
+	if cert == nil {
+		return clients.CertificateResponse{}
+	}
+	return clients.CertificateResponse{
+		DNSNames:            cert.DNSNames,
+		Emails:              cert.EmailAddresses,
+		IssuerCommonName:    cert.Issuer.CommonName,
+		IssuerOrganization:  cert.Issuer.Organization,
+		SubjectCommonName:   cert.Subject.CommonName,
+		SubjectOrganization: cert.Subject.Organization,
+	}
+}
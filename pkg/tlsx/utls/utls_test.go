@@ -0,0 +1,49 @@
+package utls
+
+import (
+	"context"
+	"testing"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+)
+
+// TestNewKnownFingerprint ensures New resolves each supported fingerprint
+// name to its real uTLS ClientHelloID.
+func TestNewKnownFingerprint(t *testing.T) {
+	for name := range clientHelloIDs {
+		client, err := New(&clients.Options{ClientHello: name})
+		if err != nil {
+			t.Fatalf("unexpected error creating client for %q: %v", name, err)
+		}
+		if client.clientHello != clientHelloIDs[name] {
+			t.Fatalf("expected %q to resolve to %v, got %v", name, clientHelloIDs[name], client.clientHello)
+		}
+	}
+}
+
+// TestNewUnknownFingerprint ensures New rejects a fingerprint name it
+// doesn't recognize instead of trying a custom-JA3 lookup that doesn't
+// exist in refraction-networking/utls.
+func TestNewUnknownFingerprint(t *testing.T) {
+	if _, err := New(&clients.Options{ClientHello: "769,47-53,0-10-11,23,0"}); err == nil {
+		t.Fatal("expected an error for an unrecognized client hello fingerprint")
+	}
+}
+
+// TestConnectContextCancelled ensures ConnectContext returns promptly with
+// ctx.Err() instead of blocking on the dial when the context is already
+// cancelled before the handshake begins.
+func TestConnectContextCancelled(t *testing.T) {
+	client, err := New(&clients.Options{Timeout: 5, ClientHello: "chrome"})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = client.ConnectContext(ctx, "example.com", "443")
+	if err == nil {
+		t.Fatal("expected an error from ConnectContext with an already-cancelled context")
+	}
+}
@@ -3,13 +3,16 @@
 package ztls
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	ctls "github.com/projectdiscovery/tlsx/pkg/tlsx/tls"
 	"github.com/zmap/zcrypto/tls"
 	"github.com/zmap/zcrypto/x509"
 )
@@ -18,6 +21,12 @@ import (
 type Client struct {
 	dialer    *net.Dialer
 	tlsConfig *tls.Config
+	// noSNI makes Connect behave like ConnectWithNoSNI
+	noSNI bool
+
+	// fallback is the standard library crypto/tls backend used to
+	// serve requests zcrypto/tls cannot negotiate, such as TLS 1.3.
+	fallback *ctls.Client
 }
 
 // versionStringToTLSVersion converts tls version string to version
@@ -37,7 +46,25 @@ var versionToTLSVersionString = map[uint16]string{
 }
 
 // New creates a new grabbing client using crypto/tls
+//
+// zcrypto/tls does not implement TLS 1.3, so requesting it (either as
+// MinVersion or MaxVersion) delegates the whole client to the standard
+// library crypto/tls backend instead.
 func New(options *clients.Options) (*Client, error) {
+	if options.MinVersion == "tls13" || options.MaxVersion == "tls13" {
+		if options.MinVersion == "ssl30" {
+			return nil, fmt.Errorf("ssl30 cannot be combined with tls13: the standard library crypto/tls fallback does not implement SSLv3")
+		}
+		if options.CertsOnly {
+			return nil, fmt.Errorf("certs-only mode is not supported when falling back to the standard library tls13 backend")
+		}
+		fallback, err := ctls.New(options)
+		if err != nil {
+			return nil, err
+		}
+		return &Client{fallback: fallback, noSNI: options.NoSNI}, nil
+	}
+
 	c := &Client{
 		dialer: &net.Dialer{
 			Timeout: time.Duration(options.Timeout) * time.Second,
@@ -48,6 +75,7 @@ func New(options *clients.Options) (*Client, error) {
 			MaxVersion:         tls.VersionTLS12,
 			InsecureSkipVerify: !options.VerifyServerCertificate,
 		},
+		noSNI: options.NoSNI,
 	}
 	if options.ServerName != "" {
 		c.tlsConfig.ServerName = options.ServerName
@@ -68,29 +96,96 @@ func New(options *clients.Options) (*Client, error) {
 			c.tlsConfig.MaxVersion = version
 		}
 	}
+	if options.ALPN != "" {
+		c.tlsConfig.NextProtos = clients.SplitAndTrim(options.ALPN)
+	}
+	if options.ClientCertFile != "" && options.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(options.ClientCertFile, options.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate")
+		}
+		c.tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if options.ClientCAFile != "" {
+		pemBytes, err := os.ReadFile(options.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read client ca file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemBytes) {
+			return nil, fmt.Errorf("could not parse client ca file: %s", options.ClientCAFile)
+		}
+		c.tlsConfig.RootCAs = pool
+	}
 	return c, nil
 }
 
-type timeoutError struct{}
-
-func (timeoutError) Error() string   { return "tls: DialWithDialer timed out" }
-func (timeoutError) Timeout() bool   { return true }
-func (timeoutError) Temporary() bool { return true }
-
 // Connect connects to a host and grabs the response data
 func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
-	address := net.JoinHostPort(hostname, port)
-	timeout := c.dialer.Timeout
+	return c.ConnectContext(context.Background(), hostname, port)
+}
+
+// ConnectContext connects to a host and grabs the response data, aborting
+// the dial and handshake as soon as ctx is cancelled, its deadline is
+// exceeded, or the client's configured Timeout elapses.
+func (c *Client) ConnectContext(ctx context.Context, hostname, port string) (*clients.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+	return c.connect(ctx, hostname, port, c.noSNI)
+}
 
-	var errChannel chan error
-	if timeout != 0 {
-		errChannel = make(chan error, 2)
-		time.AfterFunc(timeout, func() {
-			errChannel <- timeoutError{}
-		})
+// ConnectWithNoSNI connects to a host without setting the SNI ServerName,
+// useful for discovering the default vhost certificate served by shared
+// hosting and CDNs.
+func (c *Client) ConnectWithNoSNI(hostname, port string) (*clients.Response, error) {
+	ctx, cancel := c.withTimeout(context.Background())
+	defer cancel()
+	return c.connect(ctx, hostname, port, true)
+}
+
+// ConnectDifferentialSNI performs two handshakes against the same host,
+// one without SNI and one with it set, and returns both responses so the
+// caller can diff the default-vhost certificate against the vhost-specific
+// one.
+func (c *Client) ConnectDifferentialSNI(hostname, port string) (*clients.DifferentialResponse, error) {
+	noSNI, err := c.ConnectWithNoSNI(hostname, port)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect without sni")
+	}
+	ctx, cancel := c.withTimeout(context.Background())
+	defer cancel()
+	withSNI, err := c.connect(ctx, hostname, port, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not connect with sni")
 	}
+	return &clients.DifferentialResponse{
+		NoSNI:              noSNI,
+		SNI:                withSNI,
+		VersionDiffers:     noSNI.Version != withSNI.Version,
+		CertificateDiffers: clients.CertificatesDiffer(noSNI, withSNI),
+	}, nil
+}
+
+// withTimeout derives a context bound to the client's configured dial
+// timeout, or a no-op cancel if no timeout was configured.
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.dialer == nil || c.dialer.Timeout == 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.dialer.Timeout)
+}
 
-	conn, err := c.dialer.Dial("tcp", address)
+func (c *Client) connect(ctx context.Context, hostname, port string, noSNI bool) (*clients.Response, error) {
+	if c.fallback != nil {
+		if noSNI {
+			return c.fallback.ConnectWithNoSNIContext(ctx, hostname, port)
+		}
+		return c.fallback.ConnectContext(ctx, hostname, port)
+	}
+
+	address := net.JoinHostPort(hostname, port)
+
+	conn, err := c.dialer.DialContext(ctx, "tcp", address)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not connect to address")
 	}
@@ -102,21 +197,39 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 	}
 	hostnameValue := address[:colonPos]
 
-	config := c.tlsConfig
-	if config.ServerName == "" {
-		c := *config
-		c.ServerName = hostnameValue
-		config = &c
+	config := c.cloneTLSConfig()
+	if !noSNI && config.ServerName == "" {
+		config.ServerName = hostnameValue
+	} else if noSNI && config.ServerName != "" {
+		config.ServerName = ""
+	}
+
+	var certRequest *tls.CertificateRequestInfo
+	clientCerts := config.Certificates
+	config.GetClientCertificate = func(info *tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		certRequest = info
+		if len(clientCerts) > 0 {
+			return &clientCerts[0], nil
+		}
+		return &tls.Certificate{}, nil
 	}
 
-	tlsConn := tls.Client(conn, c.tlsConfig)
-	if timeout == 0 {
-		err = tlsConn.Handshake()
-	} else {
-		go func() {
-			errChannel <- tlsConn.Handshake()
-		}()
-		err = <-errChannel
+	tlsConn := tls.Client(conn, config)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- tlsConn.Handshake()
+	}()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Unblock the handshake goroutine by forcing the underlying
+		// connection to time out immediately; the goroutine's result
+		// is then discarded once it returns.
+		_ = conn.SetDeadline(time.Now())
+		<-done
+		return nil, ctx.Err()
 	}
 	if err == tls.ErrCertsOnly {
 		err = nil
@@ -128,11 +241,19 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 
 	tlsVersion := versionToTLSVersionString[uint16(hl.ServerHello.Version)]
 	response := &clients.Response{
-		Host:          hostname,
-		Port:          port,
-		Version:       tlsVersion,
-		TLSConnection: "ztls",
-		Leaf:          convertCertificateToResponse(parseSimpleTLSCertificate(hl.ServerCertificates.Certificate)),
+		Host:             hostname,
+		Port:             port,
+		Version:          tlsVersion,
+		TLSConnection:    "ztls",
+		Leaf:             convertCertificateToResponse(parseSimpleTLSCertificate(hl.ServerCertificates.Certificate)),
+		NegotiatedCipher: cipherSuiteName(hl.ServerHello.CipherSuite),
+	}
+	response.NegotiatedProtocol = hl.ServerHello.AlpnProtocol
+	if certRequest != nil {
+		response.ClientAuthRequested = true
+		for _, ca := range certRequest.AcceptableCAs {
+			response.AcceptedIssuers = append(response.AcceptedIssuers, clients.IssuerDN(ca))
+		}
 	}
 	for _, cert := range hl.ServerCertificates.Chain {
 		response.Chain = append(response.Chain, convertCertificateToResponse(parseSimpleTLSCertificate(cert)))
@@ -140,6 +261,128 @@ func (c *Client) Connect(hostname, port string) (*clients.Response, error) {
 	return response, nil
 }
 
+// EnumerateALPN sweeps the supplied list of application protocols,
+// performing one handshake per protocol with only that protocol offered,
+// and returns the set of protocols the server negotiated.
+func (c *Client) EnumerateALPN(hostname, port string, protocols []string) ([]string, error) {
+	if c.fallback != nil {
+		return c.fallback.EnumerateALPN(hostname, port, protocols)
+	}
+	return clients.EnumerateALPN(protocols, func(proto string) (*clients.Response, error) {
+		probe := *c
+		cfg := c.cloneTLSConfig()
+		cfg.NextProtos = []string{proto}
+		probe.tlsConfig = cfg
+		return probe.Connect(hostname, port)
+	}), nil
+}
+
+// EnumerateCiphers iterates every cipher suite known to zcrypto/tls
+// across the client's configured [MinVersion, MaxVersion] range,
+// performing one handshake per cipher/version pair, and reports the
+// ones the server accepted. It also probes whether the server enforces
+// its own cipher preference by offering the accepted set in two
+// different orders.
+func (c *Client) EnumerateCiphers(hostname, port string) ([]clients.CipherResponse, bool, error) {
+	if c.fallback != nil {
+		return nil, false, fmt.Errorf("cipher enumeration is not supported when using the standard library tls13 fallback backend")
+	}
+
+	var supported []clients.CipherResponse
+	var serverPreference bool
+
+	for version := c.tlsConfig.MinVersion; version <= c.tlsConfig.MaxVersion; version++ {
+		var acceptedIDs []uint16
+		for _, suite := range tls.CipherSuites() {
+			probe := *c
+			cfg := c.cloneTLSConfig()
+			cfg.MinVersion = version
+			cfg.MaxVersion = version
+			cfg.CipherSuites = []uint16{suite.ID}
+			probe.tlsConfig = cfg
+
+			resp, err := probe.Connect(hostname, port)
+			if err != nil {
+				continue
+			}
+			acceptedIDs = append(acceptedIDs, suite.ID)
+			supported = append(supported, clients.CipherResponse{
+				Version:  resp.Version,
+				Cipher:   suite.Name,
+				Insecure: clients.IsWeakCipher(suite.Name),
+			})
+		}
+
+		if pref, err := c.hasServerCipherPreference(hostname, port, version, acceptedIDs); err == nil && pref {
+			serverPreference = true
+		}
+	}
+	return supported, serverPreference, nil
+}
+
+// hasServerCipherPreference offers the accepted cipher set in two
+// different orders and reports whether the server picked the same
+// cipher both times, indicating it enforces its own preference rather
+// than honouring the client's order.
+func (c *Client) hasServerCipherPreference(hostname, port string, version uint16, acceptedIDs []uint16) (bool, error) {
+	if len(acceptedIDs) < 2 {
+		return false, fmt.Errorf("not enough accepted ciphers to determine preference")
+	}
+	reversed := make([]uint16, len(acceptedIDs))
+	for i, id := range acceptedIDs {
+		reversed[len(acceptedIDs)-1-i] = id
+	}
+
+	first := *c
+	firstCfg := c.cloneTLSConfig()
+	firstCfg.MinVersion, firstCfg.MaxVersion = version, version
+	firstCfg.CipherSuites = acceptedIDs
+	first.tlsConfig = firstCfg
+
+	second := *c
+	secondCfg := c.cloneTLSConfig()
+	secondCfg.MinVersion, secondCfg.MaxVersion = version, version
+	secondCfg.CipherSuites = reversed
+	second.tlsConfig = secondCfg
+
+	firstResp, err := first.Connect(hostname, port)
+	if err != nil {
+		return false, err
+	}
+	secondResp, err := second.Connect(hostname, port)
+	if err != nil {
+		return false, err
+	}
+	return firstResp.NegotiatedCipher == secondResp.NegotiatedCipher, nil
+}
+
+// cloneTLSConfig builds a fresh *tls.Config with the same settings as the
+// client's, field by field. zcrypto's tls.Config embeds a mutex, so it must
+// never be copied by dereferencing (go vet: assignment copies lock value).
+func (c *Client) cloneTLSConfig() *tls.Config {
+	return &tls.Config{
+		CertsOnly:          c.tlsConfig.CertsOnly,
+		MinVersion:         c.tlsConfig.MinVersion,
+		MaxVersion:         c.tlsConfig.MaxVersion,
+		InsecureSkipVerify: c.tlsConfig.InsecureSkipVerify,
+		ServerName:         c.tlsConfig.ServerName,
+		NextProtos:         c.tlsConfig.NextProtos,
+		CipherSuites:       c.tlsConfig.CipherSuites,
+		Certificates:       c.tlsConfig.Certificates,
+		RootCAs:            c.tlsConfig.RootCAs,
+	}
+}
+
+// cipherSuiteName resolves a cipher suite id to its name
+func cipherSuiteName(id tls.CipherSuiteID) string {
+	for _, suite := range tls.CipherSuites() {
+		if suite.ID == uint16(id) {
+			return suite.Name
+		}
+	}
+	return fmt.Sprintf("0x%04X", uint16(id))
+}
+
 func parseSimpleTLSCertificate(cert tls.SimpleCertificate) *x509.Certificate {
 	parsed, _ := x509.ParseCertificate(cert.Raw)
 	return parsed
@@ -0,0 +1,142 @@
+package ztls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/tlsx/pkg/tlsx/clients"
+	"github.com/zmap/zcrypto/tls"
+)
+
+// TestNewTLS13FallbackInvalidCombinations ensures New rejects the option
+// combinations the standard library tls13 fallback backend can't honor,
+// instead of silently building a client that would misbehave.
+func TestNewTLS13FallbackInvalidCombinations(t *testing.T) {
+	if _, err := New(&clients.Options{MinVersion: "ssl30", MaxVersion: "tls13"}); err == nil {
+		t.Fatal("expected an error combining ssl30 with a tls13 fallback, got nil")
+	}
+	if _, err := New(&clients.Options{MaxVersion: "tls13", CertsOnly: true}); err == nil {
+		t.Fatal("expected an error combining CertsOnly with a tls13 fallback, got nil")
+	}
+}
+
+// TestEnumerateCiphersTLS13Fallback ensures a client that delegates to the
+// standard library tls13 backend returns a clear error from cipher
+// enumeration instead of panicking on the nil zcrypto/tls config.
+func TestEnumerateCiphersTLS13Fallback(t *testing.T) {
+	client, err := New(&clients.Options{MaxVersion: "tls13"})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	_, _, err = client.EnumerateCiphers("example.com", "443")
+	if err == nil {
+		t.Fatal("expected an error enumerating ciphers on a tls13 fallback client, got nil")
+	}
+}
+
+// TestEnumerateALPNTLS13Fallback ensures EnumerateALPN delegates to the
+// fallback backend rather than dereferencing the nil zcrypto/tls config.
+func TestEnumerateALPNTLS13Fallback(t *testing.T) {
+	client, err := New(&clients.Options{MaxVersion: "tls13"})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+
+	if _, err := client.EnumerateALPN("127.0.0.1", "1", []string{"h2"}); err != nil {
+		t.Fatalf("unexpected error from EnumerateALPN on a tls13 fallback client: %v", err)
+	}
+}
+
+// TestCipherSuiteName ensures cipherSuiteName resolves against zcrypto's
+// tls.CipherSuiteID, the type ServerHello.CipherSuite actually carries, and
+// falls back to a hex representation for ids zcrypto doesn't know about.
+func TestCipherSuiteName(t *testing.T) {
+	known := tls.CipherSuites()[0]
+	if name := cipherSuiteName(tls.CipherSuiteID(known.ID)); name != known.Name {
+		t.Fatalf("expected %s, got %s", known.Name, name)
+	}
+
+	if name := cipherSuiteName(tls.CipherSuiteID(0xFFFF)); name != "0xFFFF" {
+		t.Fatalf("expected hex fallback for an unknown cipher suite id, got %s", name)
+	}
+}
+
+// TestNegotiatedProtocolFromHandshakeLog ensures the negotiated ALPN
+// protocol is read straight off zcrypto's ServerHello.AlpnProtocol, which
+// is a plain string rather than a *string.
+func TestNegotiatedProtocolFromHandshakeLog(t *testing.T) {
+	hl := &tls.ServerHandshake{ServerHello: &tls.ServerHello{AlpnProtocol: "h2"}}
+
+	response := &clients.Response{}
+	response.NegotiatedProtocol = hl.ServerHello.AlpnProtocol
+
+	if response.NegotiatedProtocol != "h2" {
+		t.Fatalf("expected negotiated protocol h2, got %s", response.NegotiatedProtocol)
+	}
+}
+
+// TestNewClientCertificate ensures New loads a PEM client certificate/key
+// pair and CA bundle into zcrypto's tls.Config, so connect's
+// GetClientCertificate callback has a certificate to present.
+func TestNewClientCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	writeSelfSignedCert(t, certPath, keyPath)
+
+	client, err := New(&clients.Options{
+		ClientCertFile: certPath,
+		ClientKeyFile:  keyPath,
+		ClientCAFile:   certPath,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating client: %v", err)
+	}
+	if len(client.tlsConfig.Certificates) != 1 {
+		t.Fatalf("expected 1 client certificate loaded, got %d", len(client.tlsConfig.Certificates))
+	}
+	if client.tlsConfig.RootCAs == nil {
+		t.Fatal("expected RootCAs to be populated from ClientCAFile")
+	}
+}
+
+func writeSelfSignedCert(t *testing.T, certPath, keyPath string) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate key: %v", err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tlsx-test"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatalf("could not create certificate: %v", err)
+	}
+	certBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certPath, certBytes, 0o600); err != nil {
+		t.Fatalf("could not write cert file: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatalf("could not marshal key: %v", err)
+	}
+	keyBytes := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyPath, keyBytes, 0o600); err != nil {
+		t.Fatalf("could not write key file: %v", err)
+	}
+}